@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// accountsManifestPath returns where the `--account` CLI persists the list
+// of configured accounts, alongside the per-account tokens in tokensDir().
+func accountsManifestPath() string {
+	return filepath.Join(filepath.Dir(tokensDir()), "accounts.json")
+}
+
+func loadAccountManifest() ([]AccountConfig, error) {
+	b, err := os.ReadFile(accountsManifestPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var accounts []AccountConfig
+	if err := json.Unmarshal(b, &accounts); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %v", accountsManifestPath(), err)
+	}
+	return accounts, nil
+}
+
+func saveAccountManifest(accounts []AccountConfig) error {
+	path := accountsManifestPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(accounts, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0600)
+}
+
+// accountAdd registers a new calendar account, driving the OAuth flow to
+// cache its token under tokensDir() before the account can be used by the
+// component.
+func accountAdd(args []string) error {
+	fs := flag.NewFlagSet("account add", flag.ExitOnError)
+	name := fs.String("name", "", "unique name for this account (required)")
+	calendarID := fs.String("calendar-id", PRIMARY_CALENDAR, "calendar ID to watch")
+	credentialsFile := fs.String("credentials-file", CREDENTIALS_FILE, "path to the OAuth client secret JSON")
+	tokenFile := fs.String("token-file", "", "path to cache the OAuth token (defaults under tokensDir())")
+	isWork := fs.Bool("work", false, "treat this as a work calendar for business-hours priority")
+	oauthBind := fs.String("oauth-bind", "", "address the loopback OAuth callback server binds to (defaults to localhost:0)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return fmt.Errorf("--name is required")
+	}
+
+	bindAddr, err := parseBindAddr(*oauthBind)
+	if err != nil {
+		return err
+	}
+
+	accounts, err := loadAccountManifest()
+	if err != nil {
+		return err
+	}
+	for _, a := range accounts {
+		if a.Name == *name {
+			return fmt.Errorf("account %q already exists", *name)
+		}
+	}
+
+	acct := AccountConfig{
+		Name:            *name,
+		CalendarID:      *calendarID,
+		CredentialsFile: *credentialsFile,
+		TokenFile:       *tokenFile,
+		IsWork:          *isWork,
+	}
+
+	if err := os.MkdirAll(filepath.Dir(acct.tokenFile()), 0700); err != nil {
+		return err
+	}
+	if _, err := newCalendarService(acct.auth(bindAddr)); err != nil {
+		return fmt.Errorf("unable to authenticate account %q: %v", *name, err)
+	}
+
+	accounts = append(accounts, acct)
+	if err := saveAccountManifest(accounts); err != nil {
+		return err
+	}
+
+	fmt.Printf("Added account %q (calendar %q)\n", acct.Name, acct.CalendarID)
+	return nil
+}
+
+// accountList prints the accounts registered via accountAdd.
+func accountList() error {
+	accounts, err := loadAccountManifest()
+	if err != nil {
+		return err
+	}
+	if len(accounts) == 0 {
+		fmt.Println("No accounts configured.")
+		return nil
+	}
+	for _, a := range accounts {
+		fmt.Printf("%s\tcalendar=%s\twork=%t\ttoken=%s\n", a.Name, a.CalendarID, a.IsWork, a.tokenFile())
+	}
+	return nil
+}
+
+// accountRemove deletes an account from the manifest and its cached token.
+func accountRemove(args []string) error {
+	fs := flag.NewFlagSet("account remove", flag.ExitOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: workin-wheel --account remove <name>")
+	}
+	name := fs.Arg(0)
+
+	accounts, err := loadAccountManifest()
+	if err != nil {
+		return err
+	}
+
+	kept := accounts[:0]
+	var removed *AccountConfig
+	for _, a := range accounts {
+		if a.Name == name {
+			a := a
+			removed = &a
+			continue
+		}
+		kept = append(kept, a)
+	}
+	if removed == nil {
+		return fmt.Errorf("no account named %q", name)
+	}
+
+	if err := saveAccountManifest(kept); err != nil {
+		return err
+	}
+	os.Remove(removed.tokenFile())
+
+	fmt.Printf("Removed account %q\n", name)
+	return nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AccountConfig describes one calendar to watch: personal, work, a shared
+// on-call calendar, etc. Each account authenticates independently, so a
+// work and a personal Google account can both feed the same wheel.
+type AccountConfig struct {
+	Name            string `yaml:"name" json:"name"`
+	CalendarID      string `yaml:"calendar_id" json:"calendar_id"`
+	CredentialsFile string `yaml:"credentials_file" json:"credentials_file"`
+	TokenFile       string `yaml:"token_file,omitempty" json:"token_file,omitempty"`
+	IsWork          bool   `yaml:"is_work,omitempty" json:"is_work,omitempty"`
+}
+
+func (a AccountConfig) tokenFile() string {
+	if a.TokenFile != "" {
+		return a.TokenFile
+	}
+	return filepath.Join(tokensDir(), a.Name+".json")
+}
+
+func (a AccountConfig) auth(bindAddr string) *calendarAuth {
+	return &calendarAuth{
+		CredentialsFile: a.CredentialsFile,
+		TokenFile:       a.tokenFile(),
+		OAuthBindAddr:   bindAddr,
+	}
+}
+
+// tokensDir is where per-account OAuth tokens are cached, replacing the
+// single hardcoded token.json.
+func tokensDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, ".workin-wheel", "tokens")
+}
+
+// AccountSet fetches the next wheel position for every configured account
+// in parallel and resolves conflicts between them down to a single
+// WheelState.
+type AccountSet struct {
+	accounts []AccountConfig
+	cfg      *WheelConfig
+	bindAddr string
+	logger   func(format string, args ...interface{})
+}
+
+func newAccountSet(cfg *WheelConfig, accounts []AccountConfig, bindAddr string) *AccountSet {
+	return &AccountSet{cfg: cfg, accounts: accounts, bindAddr: bindAddr, logger: log.Printf}
+}
+
+// NextWheelPosition fetches every account's next event in parallel and
+// resolves the wheel position to use. An individual account's fetch
+// failing (e.g. an expired token or a transient network error) is logged
+// and that account is excluded from the aggregation rather than aborting
+// the whole poll cycle; only when every account fails does this return an
+// error.
+func (a *AccountSet) NextWheelPosition(ctx context.Context) (WheelState, error) {
+	if len(a.accounts) == 0 {
+		return resolvedState(a.cfg, a.cfg.DefaultPositionIndex(), "", "", ""), fmt.Errorf("no accounts configured")
+	}
+
+	states := make([]WheelState, len(a.accounts))
+	errs := make([]error, len(a.accounts))
+	var wg sync.WaitGroup
+	for i, acct := range a.accounts {
+		i, acct := i, acct
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			state, err := getNextWheelPosition(a.cfg, acct.auth(a.bindAddr), acct.CalendarID)
+			if err != nil {
+				errs[i] = fmt.Errorf("account %q: %v", acct.Name, err)
+				return
+			}
+			states[i] = state
+		}()
+	}
+	wg.Wait()
+
+	var best WheelState
+	var bestAcct AccountConfig
+	haveBest := false
+	for i, err := range errs {
+		if err != nil {
+			a.logger("skipping account in wheel aggregation: %v", err)
+			continue
+		}
+		if !haveBest || preferState(a.cfg, states[i], a.accounts[i], best, bestAcct) {
+			best = states[i]
+			bestAcct = a.accounts[i]
+			haveBest = true
+		}
+	}
+	if !haveBest {
+		return resolvedState(a.cfg, a.cfg.DefaultPositionIndex(), "", "", ""), fmt.Errorf("all %d accounts failed: %s", len(errs), joinErrors(errs))
+	}
+
+	return best, nil
+}
+
+// joinErrors concatenates the non-nil errors in errs into a single
+// message, for the all-accounts-failed error above.
+func joinErrors(errs []error) string {
+	var msgs []string
+	for _, err := range errs {
+		if err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// preferState decides whether candidate should win over current, applying
+// the priority rules in order: focusTime beats outOfOffice (per cfg's
+// FocusTimePosition/OutOfOfficePosition roles, when both are assigned), an
+// already-started meeting beats one that's merely upcoming, a work
+// calendar beats a personal one during business hours, and otherwise the
+// lower (more urgent) slice position wins.
+func preferState(cfg *WheelConfig, candidate WheelState, candidateAcct AccountConfig, current WheelState, currentAcct AccountConfig) bool {
+	if focusIdx, ok := cfg.FocusTimePositionIndex(); ok {
+		if oooIdx, ok := cfg.OutOfOfficePositionIndex(); ok {
+			if candidate.Position == focusIdx && current.Position == oooIdx {
+				return true
+			}
+			if current.Position == focusIdx && candidate.Position == oooIdx {
+				return false
+			}
+		}
+	}
+
+	candidateActive := eventInProgress(candidate)
+	currentActive := eventInProgress(current)
+	if candidateActive != currentActive {
+		return candidateActive
+	}
+
+	if isBusinessHours(time.Now()) && candidateAcct.IsWork != currentAcct.IsWork {
+		return candidateAcct.IsWork
+	}
+
+	return candidate.Position < current.Position
+}
+
+func eventInProgress(state WheelState) bool {
+	start, err := time.Parse(time.RFC3339, state.EventStart)
+	if err != nil || start.After(time.Now()) {
+		return false
+	}
+	if state.EventEnd == "" {
+		return true
+	}
+	end, err := time.Parse(time.RFC3339, state.EventEnd)
+	return err != nil || end.After(time.Now())
+}
+
+func isBusinessHours(t time.Time) bool {
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	hour := t.Hour()
+	return hour >= 9 && hour < 17
+}
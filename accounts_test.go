@@ -0,0 +1,114 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPreferState(t *testing.T) {
+	cfg := testWheelConfig(t)
+	focusTime := cfg.labelIndex["FOCUS_TIME"]
+	outOfOffice := cfg.labelIndex["OUT_OF_OFFICE"]
+	available := cfg.labelIndex["AVAILABLE"]
+	inMeeting := cfg.labelIndex["IN_MEETING"]
+
+	work := AccountConfig{Name: "work", IsWork: true}
+	personal := AccountConfig{Name: "personal", IsWork: false}
+
+	future := time.Now().Add(1 * time.Hour).Format(time.RFC3339)
+	past := time.Now().Add(-1 * time.Hour).Format(time.RFC3339)
+
+	cases := []struct {
+		name      string
+		candidate WheelState
+		candAcct  AccountConfig
+		current   WheelState
+		currAcct  AccountConfig
+		want      bool
+	}{
+		{
+			name:      "focus time beats out of office",
+			candidate: WheelState{Position: focusTime, EventStart: future},
+			candAcct:  personal,
+			current:   WheelState{Position: outOfOffice, EventStart: future},
+			currAcct:  personal,
+			want:      true,
+		},
+		{
+			name:      "out of office does not beat focus time",
+			candidate: WheelState{Position: outOfOffice, EventStart: future},
+			candAcct:  personal,
+			current:   WheelState{Position: focusTime, EventStart: future},
+			currAcct:  personal,
+			want:      false,
+		},
+		{
+			name:      "already-started meeting beats merely upcoming one",
+			candidate: WheelState{Position: inMeeting, EventStart: past},
+			candAcct:  personal,
+			current:   WheelState{Position: available, EventStart: future},
+			currAcct:  personal,
+			want:      true,
+		},
+		{
+			name:      "upcoming meeting does not beat an active one",
+			candidate: WheelState{Position: available, EventStart: future},
+			candAcct:  personal,
+			current:   WheelState{Position: inMeeting, EventStart: past},
+			currAcct:  personal,
+			want:      false,
+		},
+		{
+			name:      "lower position wins when otherwise tied",
+			candidate: WheelState{Position: outOfOffice, EventStart: future},
+			candAcct:  personal,
+			current:   WheelState{Position: available, EventStart: future},
+			currAcct:  personal,
+			want:      true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := preferState(cfg, tc.candidate, tc.candAcct, tc.current, tc.currAcct); got != tc.want {
+				t.Errorf("preferState() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("work beats personal during business hours", func(t *testing.T) {
+		// preferState reads time.Now() directly rather than taking a clock
+		// parameter, so this assertion only applies when the test happens
+		// to run during business hours.
+		if !isBusinessHours(time.Now()) {
+			t.Skip("not currently business hours; skipping time-dependent assertion")
+		}
+
+		candidate := WheelState{Position: available, EventStart: future}
+		current := WheelState{Position: available, EventStart: future}
+		if !preferState(cfg, candidate, work, current, personal) {
+			t.Errorf("preferState() = false, want true (work account should win during business hours)")
+		}
+	})
+}
+
+func TestPreferStateIgnoresFocusOutOfOfficeRuleWhenRolesUnassigned(t *testing.T) {
+	cfg := testWheelConfig(t)
+	cfg.FocusTimePosition = ""
+	cfg.OutOfOfficePosition = ""
+
+	focusTime := cfg.labelIndex["FOCUS_TIME"]
+	outOfOffice := cfg.labelIndex["OUT_OF_OFFICE"]
+	personal := AccountConfig{Name: "personal"}
+
+	future := time.Now().Add(1 * time.Hour).Format(time.RFC3339)
+
+	candidate := WheelState{Position: outOfOffice, EventStart: future}
+	current := WheelState{Position: focusTime, EventStart: future}
+
+	// With the roles unassigned, the priority rule is skipped entirely and
+	// the fallback (lower position wins) applies instead.
+	if !preferState(cfg, candidate, personal, current, personal) {
+		t.Errorf("preferState() = false, want true (lower position should win once the focus/ooo roles are unassigned)")
+	}
+}
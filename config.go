@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// SliceConfig describes one physical position on the wheel. Power and
+// Duration are both optional per-slice overrides consumed by
+// wheelPositioner.MoveTo: Power replaces the motor's default RPM for the
+// move into this slice, and Duration adds a settle delay once the motor
+// reports it has arrived, for slices that need extra time to stop
+// swinging (e.g. a heavier indicator) before the next move is issued.
+type SliceConfig struct {
+	Label    string  `yaml:"label" json:"label"`
+	Power    float64 `yaml:"power" json:"power"`
+	Duration string  `yaml:"duration" json:"duration"`
+}
+
+// Rule matches an incoming calendar event against a wheel position. Rules
+// are evaluated in file order and the first match wins, so more specific
+// overrides should be listed before general-purpose ones.
+type Rule struct {
+	Name           string `yaml:"name" json:"name"`
+	CalendarID     string `yaml:"calendar_id,omitempty" json:"calendar_id,omitempty"`
+	EventType      string `yaml:"event_type,omitempty" json:"event_type,omitempty"`
+	SummaryRegex   string `yaml:"summary_regex,omitempty" json:"summary_regex,omitempty"`
+	MinAttendees   int    `yaml:"min_attendees,omitempty" json:"min_attendees,omitempty"`
+	ResponseStatus string `yaml:"response_status,omitempty" json:"response_status,omitempty"`
+	Position       string `yaml:"position" json:"position"`
+
+	summaryRe *regexp.Regexp
+}
+
+// WheelConfig is the user-supplied mapping between calendar events and
+// wheel positions, loaded from --config at startup.
+type WheelConfig struct {
+	SliceCount      int           `yaml:"slice_count" json:"slice_count"`
+	Slices          []SliceConfig `yaml:"slices" json:"slices"`
+	DefaultPosition string        `yaml:"default_position" json:"default_position"`
+	Rules           []Rule        `yaml:"rules" json:"rules"`
+
+	// FocusTimePosition and OutOfOfficePosition name which slice labels play
+	// the focus-time/out-of-office role for preferState's
+	// "focus time beats out of office" priority rule. They default to
+	// "FOCUS_TIME"/"OUT_OF_OFFICE" so existing configs keep working, but a
+	// wheel that renames or drops those slices can repoint the roles (or
+	// unset them to disable the rule) instead of silently losing it.
+	FocusTimePosition   string `yaml:"focus_time_position,omitempty" json:"focus_time_position,omitempty"`
+	OutOfOfficePosition string `yaml:"out_of_office_position,omitempty" json:"out_of_office_position,omitempty"`
+
+	labelIndex map[string]int
+}
+
+// defaultWheelConfig reproduces the previous hard-coded 6-slice behavior,
+// and is used whenever --config is not supplied.
+func defaultWheelConfig() *WheelConfig {
+	cfg := &WheelConfig{
+		SliceCount: 6,
+		Slices: []SliceConfig{
+			{Label: "OUT_OF_OFFICE"},
+			{Label: "WORK_FROM_HOME"},
+			{Label: "GOING_TO_EVENT"},
+			{Label: "FOCUS_TIME"},
+			{Label: "AVAILABLE"},
+			{Label: "IN_MEETING"},
+		},
+		DefaultPosition:     "AVAILABLE",
+		FocusTimePosition:   "FOCUS_TIME",
+		OutOfOfficePosition: "OUT_OF_OFFICE",
+		Rules: []Rule{
+			{Name: "out-of-office", EventType: "outOfOffice", Position: "OUT_OF_OFFICE"},
+			{Name: "focus-time", EventType: "focusTime", Position: "FOCUS_TIME"},
+			{Name: "default", Position: "IN_MEETING"},
+		},
+	}
+	if err := cfg.compileAndValidate(); err != nil {
+		panic(fmt.Sprintf("default wheel config is invalid: %v", err))
+	}
+	return cfg
+}
+
+// loadWheelConfig reads and validates a wheel config file. YAML is assumed
+// unless the extension is .json.
+func loadWheelConfig(path string) (*WheelConfig, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read wheel config %s: %v", path, err)
+	}
+
+	cfg := WheelConfig{FocusTimePosition: "FOCUS_TIME", OutOfOfficePosition: "OUT_OF_OFFICE"}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if err := json.Unmarshal(b, &cfg); err != nil {
+			return nil, fmt.Errorf("unable to parse wheel config %s as JSON: %v", path, err)
+		}
+	} else {
+		if err := yaml.Unmarshal(b, &cfg); err != nil {
+			return nil, fmt.Errorf("unable to parse wheel config %s as YAML: %v", path, err)
+		}
+	}
+
+	if err := cfg.compileAndValidate(); err != nil {
+		return nil, fmt.Errorf("invalid wheel config %s: %v", path, err)
+	}
+	return &cfg, nil
+}
+
+// compileAndValidate fails loudly on a bad mapping rather than silently
+// falling back to AVAILABLE at runtime.
+func (c *WheelConfig) compileAndValidate() error {
+	if c.SliceCount <= 0 {
+		return fmt.Errorf("slice_count must be positive")
+	}
+	if len(c.Slices) != c.SliceCount {
+		return fmt.Errorf("slice_count is %d but %d slices are defined", c.SliceCount, len(c.Slices))
+	}
+
+	c.labelIndex = make(map[string]int, len(c.Slices))
+	for i, slice := range c.Slices {
+		if slice.Label == "" {
+			return fmt.Errorf("slice %d has no label", i)
+		}
+		if slice.Duration != "" {
+			if _, err := time.ParseDuration(slice.Duration); err != nil {
+				return fmt.Errorf("slice %q has invalid duration %q: %v", slice.Label, slice.Duration, err)
+			}
+		}
+		if _, exists := c.labelIndex[slice.Label]; exists {
+			return fmt.Errorf("duplicate slice label %q", slice.Label)
+		}
+		c.labelIndex[slice.Label] = i
+	}
+
+	if c.DefaultPosition == "" {
+		return fmt.Errorf("default_position is required")
+	}
+	if _, ok := c.labelIndex[c.DefaultPosition]; !ok {
+		return fmt.Errorf("default_position %q does not match any slice label", c.DefaultPosition)
+	}
+
+	if c.FocusTimePosition != "" {
+		if _, ok := c.labelIndex[c.FocusTimePosition]; !ok {
+			return fmt.Errorf("focus_time_position %q does not match any slice label", c.FocusTimePosition)
+		}
+	}
+	if c.OutOfOfficePosition != "" {
+		if _, ok := c.labelIndex[c.OutOfOfficePosition]; !ok {
+			return fmt.Errorf("out_of_office_position %q does not match any slice label", c.OutOfOfficePosition)
+		}
+	}
+
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		if rule.Position == "" {
+			return fmt.Errorf("rule %d (%s) has no position", i, rule.Name)
+		}
+		if _, ok := c.labelIndex[rule.Position]; !ok {
+			return fmt.Errorf("rule %d (%s) position %q does not match any slice label", i, rule.Name, rule.Position)
+		}
+		if rule.SummaryRegex != "" {
+			re, err := regexp.Compile(rule.SummaryRegex)
+			if err != nil {
+				return fmt.Errorf("rule %d (%s) has invalid summary_regex: %v", i, rule.Name, err)
+			}
+			rule.summaryRe = re
+		}
+	}
+
+	return nil
+}
+
+// PositionIndex resolves a slice label to its wheel position, returning
+// ok=false if the label is not defined in this config.
+func (c *WheelConfig) PositionIndex(label string) (int, bool) {
+	idx, ok := c.labelIndex[label]
+	return idx, ok
+}
+
+// DefaultPositionIndex resolves the configured default_position.
+func (c *WheelConfig) DefaultPositionIndex() int {
+	return c.labelIndex[c.DefaultPosition]
+}
+
+// FocusTimePositionIndex resolves the configured focus_time_position,
+// returning ok=false if the role isn't assigned to any slice.
+func (c *WheelConfig) FocusTimePositionIndex() (int, bool) {
+	if c.FocusTimePosition == "" {
+		return 0, false
+	}
+	idx, ok := c.labelIndex[c.FocusTimePosition]
+	return idx, ok
+}
+
+// OutOfOfficePositionIndex resolves the configured out_of_office_position,
+// returning ok=false if the role isn't assigned to any slice.
+func (c *WheelConfig) OutOfOfficePositionIndex() (int, bool) {
+	if c.OutOfOfficePosition == "" {
+		return 0, false
+	}
+	idx, ok := c.labelIndex[c.OutOfOfficePosition]
+	return idx, ok
+}
+
+// Label returns the slice label for a wheel position, or "UNKNOWN" if out
+// of range.
+func (c *WheelConfig) Label(position int) string {
+	if position < 0 || position >= len(c.Slices) {
+		return "UNKNOWN"
+	}
+	return c.Slices[position].Label
+}
+
+// Resolve walks the rules in order and returns the wheel position of the
+// first matching rule.
+func (c *WheelConfig) Resolve(event *calendar.Event, calendarID string) (int, bool) {
+	for _, rule := range c.Rules {
+		if rule.matches(event, calendarID) {
+			return c.labelIndex[rule.Position], true
+		}
+	}
+	return 0, false
+}
+
+// ResolveOverride is like Resolve but only considers rules that specify at
+// least one match criterion, skipping unconditional catch-all rules (such
+// as a bare "default" rule with no criteria). This lets a narrow rule, e.g.
+// a summary_regex looking for "DO NOT DISTURB", preempt the built-in
+// workingLocation/lead-time heuristics in getNextWheelPosition, while a
+// catch-all rule is left to apply only via Resolve once those heuristics
+// have had their say.
+func (c *WheelConfig) ResolveOverride(event *calendar.Event, calendarID string) (int, bool) {
+	for _, rule := range c.Rules {
+		if !rule.hasCondition() {
+			continue
+		}
+		if rule.matches(event, calendarID) {
+			return c.labelIndex[rule.Position], true
+		}
+	}
+	return 0, false
+}
+
+// hasCondition reports whether the rule specifies any match criteria. A
+// rule with none set matches every event unconditionally.
+func (r *Rule) hasCondition() bool {
+	return r.CalendarID != "" || r.EventType != "" || r.SummaryRegex != "" || r.MinAttendees > 0 || r.ResponseStatus != ""
+}
+
+func (r *Rule) matches(event *calendar.Event, calendarID string) bool {
+	if r.CalendarID != "" && r.CalendarID != calendarID {
+		return false
+	}
+	if r.EventType != "" && r.EventType != event.EventType {
+		return false
+	}
+	if r.summaryRe != nil && !r.summaryRe.MatchString(event.Summary) {
+		return false
+	}
+	if r.MinAttendees > 0 && len(event.Attendees) < r.MinAttendees {
+		return false
+	}
+	if r.ResponseStatus != "" && selfResponseStatus(event) != r.ResponseStatus {
+		return false
+	}
+	return true
+}
+
+func selfResponseStatus(event *calendar.Event) string {
+	for _, attendee := range event.Attendees {
+		if attendee.Self {
+			return attendee.ResponseStatus
+		}
+	}
+	return ""
+}
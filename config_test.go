@@ -0,0 +1,170 @@
+package main
+
+import (
+	"testing"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+func testWheelConfig(t *testing.T) *WheelConfig {
+	t.Helper()
+	cfg := &WheelConfig{
+		SliceCount: 4,
+		Slices: []SliceConfig{
+			{Label: "OUT_OF_OFFICE"},
+			{Label: "FOCUS_TIME"},
+			{Label: "AVAILABLE"},
+			{Label: "IN_MEETING"},
+		},
+		DefaultPosition:     "AVAILABLE",
+		FocusTimePosition:   "FOCUS_TIME",
+		OutOfOfficePosition: "OUT_OF_OFFICE",
+		Rules: []Rule{
+			{Name: "dnd", SummaryRegex: "DO NOT DISTURB", Position: "FOCUS_TIME"},
+			{Name: "out-of-office", EventType: "outOfOffice", Position: "OUT_OF_OFFICE"},
+			{Name: "default", Position: "IN_MEETING"},
+		},
+	}
+	if err := cfg.compileAndValidate(); err != nil {
+		t.Fatalf("test config is invalid: %v", err)
+	}
+	return cfg
+}
+
+func TestRuleMatches(t *testing.T) {
+	cfg := testWheelConfig(t)
+
+	cases := []struct {
+		name  string
+		event *calendar.Event
+		calID string
+		rule  string
+		want  bool
+	}{
+		{
+			name:  "event type matches",
+			event: &calendar.Event{EventType: "outOfOffice"},
+			calID: "primary",
+			rule:  "out-of-office",
+			want:  true,
+		},
+		{
+			name:  "event type mismatches",
+			event: &calendar.Event{EventType: "focusTime"},
+			calID: "primary",
+			rule:  "out-of-office",
+			want:  false,
+		},
+		{
+			name:  "summary regex matches",
+			event: &calendar.Event{Summary: "please DO NOT DISTURB me"},
+			calID: "primary",
+			rule:  "dnd",
+			want:  true,
+		},
+		{
+			name:  "summary regex mismatches",
+			event: &calendar.Event{Summary: "team sync"},
+			calID: "primary",
+			rule:  "dnd",
+			want:  false,
+		},
+		{
+			name:  "unconditional rule always matches",
+			event: &calendar.Event{EventType: "default"},
+			calID: "primary",
+			rule:  "default",
+			want:  true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var rule *Rule
+			for i := range cfg.Rules {
+				if cfg.Rules[i].Name == tc.rule {
+					rule = &cfg.Rules[i]
+				}
+			}
+			if rule == nil {
+				t.Fatalf("no rule named %q in test config", tc.rule)
+			}
+			if got := rule.matches(tc.event, tc.calID); got != tc.want {
+				t.Errorf("matches() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRuleHasCondition(t *testing.T) {
+	cases := []struct {
+		name string
+		rule Rule
+		want bool
+	}{
+		{name: "no criteria", rule: Rule{Name: "default", Position: "IN_MEETING"}, want: false},
+		{name: "event type set", rule: Rule{EventType: "outOfOffice"}, want: true},
+		{name: "summary regex set", rule: Rule{SummaryRegex: "DND"}, want: true},
+		{name: "min attendees set", rule: Rule{MinAttendees: 2}, want: true},
+		{name: "response status set", rule: Rule{ResponseStatus: "accepted"}, want: true},
+		{name: "calendar id set", rule: Rule{CalendarID: "work@example.com"}, want: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.rule.hasCondition(); got != tc.want {
+				t.Errorf("hasCondition() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolveOverrideSkipsUnconditionalRules(t *testing.T) {
+	cfg := testWheelConfig(t)
+
+	// An ordinary, already-started meeting has no match criteria it
+	// satisfies besides the catch-all "default" rule, which
+	// ResolveOverride must skip so the timing heuristics still get a say.
+	event := &calendar.Event{EventType: "default", Summary: "team sync"}
+	if _, ok := cfg.ResolveOverride(event, "primary"); ok {
+		t.Fatalf("ResolveOverride matched the unconditional catch-all rule, want no match")
+	}
+
+	// A rule with an explicit match criterion should still be found.
+	dndEvent := &calendar.Event{EventType: "default", Summary: "DO NOT DISTURB please"}
+	pos, ok := cfg.ResolveOverride(dndEvent, "primary")
+	if !ok {
+		t.Fatalf("ResolveOverride did not match the dnd rule")
+	}
+	if want := cfg.labelIndex["FOCUS_TIME"]; pos != want {
+		t.Errorf("ResolveOverride position = %d, want %d (FOCUS_TIME)", pos, want)
+	}
+}
+
+func TestResolveFallsBackToCatchAllRule(t *testing.T) {
+	cfg := testWheelConfig(t)
+
+	event := &calendar.Event{EventType: "default", Summary: "team sync"}
+	pos, ok := cfg.Resolve(event, "primary")
+	if !ok {
+		t.Fatalf("Resolve did not match any rule, want the catch-all default rule")
+	}
+	if want := cfg.labelIndex["IN_MEETING"]; pos != want {
+		t.Errorf("Resolve position = %d, want %d (IN_MEETING)", pos, want)
+	}
+}
+
+func TestResolveFirstMatchWins(t *testing.T) {
+	cfg := testWheelConfig(t)
+
+	// Matches both the dnd rule and the catch-all; the earlier rule in
+	// file order (dnd) must win.
+	event := &calendar.Event{EventType: "default", Summary: "DO NOT DISTURB please"}
+	pos, ok := cfg.Resolve(event, "primary")
+	if !ok {
+		t.Fatalf("Resolve did not match any rule")
+	}
+	if want := cfg.labelIndex["FOCUS_TIME"]; pos != want {
+		t.Errorf("Resolve position = %d, want %d (FOCUS_TIME)", pos, want)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/module"
+	"go.viam.com/rdk/services/generic"
+	"go.viam.com/utils"
+)
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "--account" {
+		if err := runAccountCommand(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	utils.ContextualMain(mainWithArgs, logging.NewLogger("workin-wheel"))
+}
+
+// mainWithArgs registers the workin-wheel component with the module
+// framework and serves it over the socket the parent viam-server passed in
+// on the command line, so the wheel can be added to any machine's config
+// instead of run as a side-car binary.
+func mainWithArgs(ctx context.Context, args []string, logger logging.Logger) error {
+	wheelModule, err := module.NewModuleFromArgs(ctx, logger)
+	if err != nil {
+		return err
+	}
+
+	if err := wheelModule.AddModelFromRegistry(ctx, generic.API, Model); err != nil {
+		return err
+	}
+
+	if err := wheelModule.Start(ctx); err != nil {
+		return err
+	}
+	defer wheelModule.Close(ctx)
+
+	<-ctx.Done()
+	return nil
+}
+
+// runAccountCommand handles `workin-wheel --account add|list|remove`, a
+// small standalone CLI for managing the per-account OAuth tokens under
+// tokensDir() without having to go through a running module instance.
+func runAccountCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: workin-wheel --account add|list|remove ...")
+	}
+
+	switch args[0] {
+	case "add":
+		return accountAdd(args[1:])
+	case "list":
+		return accountList()
+	case "remove":
+		return accountRemove(args[1:])
+	default:
+		return fmt.Errorf("unrecognized account subcommand %q", args[0])
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+const mqttClientIDPrefix = "workin-wheel"
+
+// mqttPublisher broadcasts the computed wheel position/status to an MQTT
+// broker so other devices (e.g. Home Assistant) can react to the user's
+// presence without needing calendar credentials themselves.
+type mqttPublisher struct {
+	client mqtt.Client
+	topic  string
+	qos    byte
+
+	mu        sync.Mutex
+	lastState WheelState
+}
+
+// mqttPayload is the JSON document published to the state topic.
+type mqttPayload struct {
+	Position     int    `json:"position"`
+	Status       string `json:"status"`
+	EventSummary string `json:"event_summary"`
+	EventStart   string `json:"event_start"`
+	EventEnd     string `json:"event_end"`
+	UpdatedAt    string `json:"updated_at"`
+}
+
+func statusTopic(topic string) string {
+	return topic + "/status"
+}
+
+// newMQTTPublisher connects to broker and registers a last-will-and-
+// testament of "offline" on the status topic, so the broker announces the
+// publisher as offline if it disconnects uncleanly. Reconnection is
+// handled by the client library with exponential backoff.
+func newMQTTPublisher(broker, topic, username, password string, qos byte) (*mqttPublisher, error) {
+	opts := mqtt.NewClientOptions()
+	opts.AddBroker(broker)
+	opts.SetClientID(fmt.Sprintf("%s-%d", mqttClientIDPrefix, time.Now().UnixNano()))
+	if username != "" {
+		opts.SetUsername(username)
+	}
+	if password != "" {
+		opts.SetPassword(password)
+	}
+	opts.SetWill(statusTopic(topic), "offline", qos, true)
+	opts.SetAutoReconnect(true)
+	opts.SetConnectRetry(true)
+	opts.SetConnectRetryInterval(5 * time.Second)
+	opts.SetMaxReconnectInterval(5 * time.Minute)
+	opts.SetOnConnectHandler(func(c mqtt.Client) {
+		log.Println("Connected to MQTT broker")
+		c.Publish(statusTopic(topic), qos, true, "online")
+	})
+	opts.SetConnectionLostHandler(func(c mqtt.Client, err error) {
+		log.Printf("Lost connection to MQTT broker: %v", err)
+	})
+
+	client := mqtt.NewClient(opts)
+	token := client.Connect()
+	if token.Wait() && token.Error() != nil {
+		return nil, fmt.Errorf("unable to connect to MQTT broker: %v", token.Error())
+	}
+
+	return &mqttPublisher{client: client, topic: topic, qos: qos}, nil
+}
+
+// Publish sends state to the topic and remembers it for the next
+// keep-alive tick.
+func (p *mqttPublisher) Publish(state WheelState) {
+	p.mu.Lock()
+	p.lastState = state
+	p.mu.Unlock()
+
+	p.publish(state)
+}
+
+// SetLastState records the most recently observed state without
+// publishing, so a subsequent keep-alive tick reports current data even
+// when the wheel position hasn't changed.
+func (p *mqttPublisher) SetLastState(state WheelState) {
+	p.mu.Lock()
+	p.lastState = state
+	p.mu.Unlock()
+}
+
+// KeepAlive republishes the last known state on every tick of interval,
+// for consumers that expect a heartbeat even when nothing has changed. It
+// runs until ctx is cancelled, so a Reconfigure/Close that replaces or
+// disconnects this publisher stops the goroutine instead of leaking it.
+func (p *mqttPublisher) KeepAlive(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			state := p.lastState
+			p.mu.Unlock()
+			p.publish(state)
+		}
+	}
+}
+
+func (p *mqttPublisher) publish(state WheelState) {
+	payload := mqttPayload{
+		Position:     state.Position,
+		Status:       state.Label,
+		EventSummary: state.EventSummary,
+		EventStart:   state.EventStart,
+		EventEnd:     state.EventEnd,
+		UpdatedAt:    time.Now().Format(time.RFC3339),
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Unable to marshal MQTT payload: %v", err)
+		return
+	}
+
+	token := p.client.Publish(p.topic, p.qos, false, body)
+	if token.Wait() && token.Error() != nil {
+		log.Printf("Unable to publish MQTT message: %v", token.Error())
+	}
+}
+
+// Close publishes offline and disconnects cleanly.
+func (p *mqttPublisher) Close() {
+	token := p.client.Publish(statusTopic(p.topic), p.qos, true, "offline")
+	token.Wait()
+	p.client.Disconnect(250)
+}
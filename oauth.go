@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// defaultOAuthBindAddr is the fallback address the loopback callback
+// server listens on when a calendarAuth doesn't specify one. "host:0" lets
+// the OS pick a free port, which is then substituted into the redirect URL
+// sent to Google.
+const defaultOAuthBindAddr = "localhost:0"
+
+const oauthCallbackTimeout = 2 * time.Minute
+
+const oauthSuccessHTML = `<!DOCTYPE html>
+<html><head><title>workin-wheel</title></head>
+<body><h1>Authentication complete</h1><p>You can close this tab and return to the terminal.</p></body></html>`
+
+// getTokenFromWeb drives the OAuth 2.0 authorization code flow using a
+// local loopback redirect: it starts a one-shot HTTP server bound to
+// bindAddr (falling back to defaultOAuthBindAddr when empty), prints the
+// consent URL for the user, and waits for Google to redirect back with the
+// authorization code. bindAddr is passed in explicitly, rather than read
+// from a package-level global, so that multiple workin-wheel instances in
+// the same process (or concurrent Reconfigure calls) can't clobber each
+// other's callback address.
+func getTokenFromWeb(config *oauth2.Config, bindAddr string) *oauth2.Token {
+	if bindAddr == "" {
+		bindAddr = defaultOAuthBindAddr
+	}
+
+	state, err := randomState()
+	if err != nil {
+		log.Fatalf("Unable to generate OAuth state: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", bindAddr)
+	if err != nil {
+		log.Fatalf("Unable to bind OAuth callback listener on %s: %v", bindAddr, err)
+	}
+
+	redirectURL := fmt.Sprintf("http://%s/", listener.Addr().String())
+	config.RedirectURL = redirectURL
+
+	codeCh := make(chan string, 1)
+	errCh := make(chan error, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("state") != state {
+			http.Error(w, "invalid state parameter", http.StatusBadRequest)
+			errCh <- fmt.Errorf("state mismatch: got %q", query.Get("state"))
+			return
+		}
+		code := query.Get("code")
+		if code == "" {
+			http.Error(w, "missing code parameter", http.StatusBadRequest)
+			errCh <- fmt.Errorf("callback request missing code parameter")
+			return
+		}
+		fmt.Fprint(w, oauthSuccessHTML)
+		codeCh <- code
+	})
+
+	server := &http.Server{Handler: mux}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+	}()
+	defer server.Shutdown(context.Background())
+
+	authURL := config.AuthCodeURL(state, oauth2.AccessTypeOffline)
+	fmt.Printf("Go to the following link in your browser:\n%v\n", authURL)
+
+	var code string
+	select {
+	case code = <-codeCh:
+	case err := <-errCh:
+		log.Fatalf("OAuth callback failed: %v", err)
+	case <-time.After(oauthCallbackTimeout):
+		log.Fatalf("Timed out waiting for OAuth callback on %s", redirectURL)
+	}
+
+	tok, err := config.Exchange(context.TODO(), code)
+	if err != nil {
+		log.Fatalf("Unable to retrieve token from web: %v", err)
+	}
+	return tok
+}
+
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// parseBindAddr validates a user-supplied oauth_bind config value,
+// defaulting the host to localhost and the port to 0 (OS-assigned) when
+// omitted.
+func parseBindAddr(raw string) (string, error) {
+	if raw == "" {
+		return "localhost:0", nil
+	}
+	host, port, err := net.SplitHostPort(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid oauth_bind %q: %v", raw, err)
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	if port == "" {
+		port = "0"
+	}
+	return net.JoinHostPort(host, port), nil
+}
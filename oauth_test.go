@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestParseBindAddr(t *testing.T) {
+	cases := []struct {
+		name    string
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty defaults to localhost:0", raw: "", want: "localhost:0"},
+		{name: "host and port given", raw: "127.0.0.1:9090", want: "127.0.0.1:9090"},
+		{name: "missing host defaults to localhost", raw: ":9090", want: "localhost:9090"},
+		{name: "missing port defaults to 0", raw: "example.com:", want: "example.com:0"},
+		{name: "no colon is invalid", raw: "localhost", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseBindAddr(tc.raw)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("parseBindAddr(%q) = %q, nil, want an error", tc.raw, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseBindAddr(%q) returned unexpected error: %v", tc.raw, err)
+			}
+			if got != tc.want {
+				t.Errorf("parseBindAddr(%q) = %q, want %q", tc.raw, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"go.viam.com/rdk/components/board"
+	"go.viam.com/rdk/components/motor"
+)
+
+// persistedPositionState is what wheelPositioner reads/writes to
+// statePath, so a restart doesn't require re-homing and doesn't forget
+// where the wheel was left.
+type persistedPositionState struct {
+	RevolutionsPerSlice float64 `json:"revolutions_per_slice"`
+	Position            int     `json:"position"`
+}
+
+// wheelPositioner drives wheelMotor in closed loop via GoFor using a
+// learned (or configured) revolutions-per-slice figure, instead of the
+// old open-loop SetPower ramp. It periodically re-homes against
+// homeInterrupt to cancel drift accumulated across many transitions.
+type wheelPositioner struct {
+	wheelMotor    motor.Motor
+	homeInterrupt board.DigitalInterrupt
+	statePath     string
+	sliceCount    int
+	rpm           float64
+	rehomeEvery   int
+	slices        []SliceConfig
+
+	mu                   sync.Mutex
+	revolutionsPerSlice  float64
+	position             int
+	transitionsSinceHome int
+}
+
+// newWheelPositioner builds a positioner for a wheel with sliceCount
+// positions. slices may be nil (no per-slice power/duration overrides);
+// when non-nil it must have sliceCount entries, indexed by position.
+func newWheelPositioner(wheelMotor motor.Motor, homeInterrupt board.DigitalInterrupt, statePath string, sliceCount int, rpm float64, rehomeEvery int, slices []SliceConfig) *wheelPositioner {
+	return &wheelPositioner{
+		wheelMotor:    wheelMotor,
+		homeInterrupt: homeInterrupt,
+		statePath:     statePath,
+		sliceCount:    sliceCount,
+		rpm:           rpm,
+		rehomeEvery:   rehomeEvery,
+		slices:        slices,
+	}
+}
+
+// Init loads a persisted calibration from statePath, or runs a one-time
+// homing routine if none exists yet. The persisted state does not record the
+// slice count, so a calibration saved for a different slice count is reused
+// as is.
+func (p *wheelPositioner) Init(ctx context.Context) error {
+	if state, err := loadPersistedPositionState(p.statePath); err == nil {
+		p.mu.Lock()
+		p.revolutionsPerSlice = state.RevolutionsPerSlice
+		p.position = state.Position
+		p.mu.Unlock()
+		return nil
+	}
+	return p.Home(ctx)
+}
+
+// Home spins the wheel against homeInterrupt to learn revolutionsPerSlice
+// and resets position to 0.
+func (p *wheelPositioner) Home(ctx context.Context) error {
+	if p.homeInterrupt == nil {
+		return fmt.Errorf("cannot home wheel: no home_interrupt configured")
+	}
+
+	revolutionsPerSlice, err := calibrateRevolutionsPerSlice(ctx, p.wheelMotor, p.homeInterrupt, p.sliceCount, p.rpm)
+	if err != nil {
+		return fmt.Errorf("homing failed: %v", err)
+	}
+
+	p.mu.Lock()
+	p.revolutionsPerSlice = revolutionsPerSlice
+	p.position = 0
+	p.transitionsSinceHome = 0
+	p.mu.Unlock()
+
+	return p.persist()
+}
+
+// MoveTo turns the wheel to target using GoFor, re-homing every
+// rehomeEvery transitions to cancel accumulated drift. If target's
+// SliceConfig sets Power or Duration, they override the positioner's
+// default RPM for this move and add a settle delay once the motor
+// reports it has arrived, respectively.
+func (p *wheelPositioner) MoveTo(ctx context.Context, target int) error {
+	p.mu.Lock()
+	slices := p.position - target
+	revolutionsPerSlice := p.revolutionsPerSlice
+	rpm := p.rpm
+	p.mu.Unlock()
+
+	rpm, settle := p.sliceOverrides(target, rpm)
+
+	if slices != 0 {
+		direction := 1.0
+		if slices < 0 {
+			direction = -1.0
+		}
+		revolutions := math.Abs(float64(slices)) * revolutionsPerSlice
+		if err := p.wheelMotor.GoFor(ctx, -direction*rpm, revolutions, nil); err != nil {
+			return err
+		}
+		if settle > 0 {
+			time.Sleep(settle)
+		}
+	}
+
+	p.mu.Lock()
+	p.position = target
+	p.transitionsSinceHome++
+	needsRehome := p.rehomeEvery > 0 && p.transitionsSinceHome >= p.rehomeEvery
+	p.mu.Unlock()
+
+	if err := p.persist(); err != nil {
+		return err
+	}
+	if needsRehome {
+		return p.Home(ctx)
+	}
+	return nil
+}
+
+// sliceOverrides resolves target's configured RPM and settle delay,
+// falling back to defaultRPM and no delay when target has no SliceConfig
+// or leaves Power/Duration unset. The duration string is assumed valid
+// since WheelConfig.compileAndValidate already rejected bad ones.
+func (p *wheelPositioner) sliceOverrides(target int, defaultRPM float64) (rpm float64, settle time.Duration) {
+	rpm = defaultRPM
+	if target < 0 || target >= len(p.slices) {
+		return rpm, 0
+	}
+	slice := p.slices[target]
+	if slice.Power != 0 {
+		rpm = slice.Power
+	}
+	if slice.Duration != "" {
+		settle, _ = time.ParseDuration(slice.Duration)
+	}
+	return rpm, settle
+}
+
+// Position returns the wheel's last known position.
+func (p *wheelPositioner) Position() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.position
+}
+
+func (p *wheelPositioner) persist() error {
+	p.mu.Lock()
+	state := persistedPositionState{RevolutionsPerSlice: p.revolutionsPerSlice, Position: p.position}
+	p.mu.Unlock()
+
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p.statePath, b, 0644)
+}
+
+func loadPersistedPositionState(path string) (persistedPositionState, error) {
+	var state persistedPositionState
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return state, err
+	}
+	err = json.Unmarshal(b, &state)
+	return state, err
+}
+
+// calibrateRevolutionsPerSlice spins the wheel past the home sensor twice
+// to measure how many motor revolutions correspond to one full trip
+// around the wheel, then divides by sliceCount.
+func calibrateRevolutionsPerSlice(ctx context.Context, wheelMotor motor.Motor, homeInterrupt board.DigitalInterrupt, sliceCount int, rpm float64) (float64, error) {
+	if err := spinUntilTrip(ctx, wheelMotor, homeInterrupt, rpm); err != nil {
+		return 0, err
+	}
+	start, err := wheelMotor.Position(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := spinUntilTrip(ctx, wheelMotor, homeInterrupt, rpm); err != nil {
+		return 0, err
+	}
+	end, err := wheelMotor.Position(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	revolutions := math.Abs(end - start)
+	if revolutions == 0 {
+		return 0, fmt.Errorf("homing measured zero motor revolutions for one full wheel rotation")
+	}
+	return revolutions / float64(sliceCount), nil
+}
+
+// spinUntilTrip runs wheelMotor until homeInterrupt ticks, then stops it.
+// It returns an error if the motor finishes maxRevolutions without a tick.
+func spinUntilTrip(ctx context.Context, wheelMotor motor.Motor, homeInterrupt board.DigitalInterrupt, rpm float64) error {
+	ticks := make(chan board.Tick, 1)
+	homeInterrupt.AddCallback(ticks)
+	defer homeInterrupt.RemoveCallback(ticks)
+
+	// A generous revolution count; spinUntilTrip stops the motor as soon
+	// as the sensor fires rather than waiting for this to complete.
+	const maxRevolutions = 100.0
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- wheelMotor.GoFor(ctx, rpm, maxRevolutions, nil)
+	}()
+
+	select {
+	case <-ticks:
+		return wheelMotor.Stop(ctx, nil)
+	case err := <-errCh:
+		if err == nil {
+			return fmt.Errorf("home sensor did not trip within %v revolutions", maxRevolutions)
+		}
+		return err
+	case <-ctx.Done():
+		wheelMotor.Stop(context.Background(), nil)
+		return ctx.Err()
+	}
+}
@@ -6,42 +6,46 @@ import (
     "fmt"
     "io/ioutil"
     "log"
-    "math"
     "os"
     "time"
 
-    "github.com/spf13/pflag"
     "golang.org/x/oauth2"
     "golang.org/x/oauth2/google"
     "google.golang.org/api/calendar/v3"
     "google.golang.org/api/option"
-    viam "go.viam.com/rdk/components/motor"
-	"go.viam.com/rdk/robot/client"
-    "go.viam.com/utils/rpc"
-	"go.viam.com/rdk/logging"
-	"go.viam.com/rdk/components/motor"
 )
 
 const (
-    SCOPES            = "https://www.googleapis.com/auth/calendar.readonly"
-    TOKEN_FILE        = "token.json"
-    CREDENTIALS_FILE  = "credentials.json"
-    OUT_OF_OFFICE     = 0
-    WORK_FROM_HOME    = 1
-    GOING_TO_EVENT    = 2
-    FOCUS_TIME        = 3
-    AVAILABLE         = 4
-    IN_MEETING        = 5
+    SCOPES           = "https://www.googleapis.com/auth/calendar.readonly"
+    TOKEN_FILE       = "token.json"
+    CREDENTIALS_FILE = "credentials.json"
+    PRIMARY_CALENDAR = "primary"
 )
 
-var eventTypeToWheelPosition = map[string]int{
-    "outOfOffice": OUT_OF_OFFICE,
-    "focusTime":   FOCUS_TIME,
-    "default":     IN_MEETING,
+// WheelState captures the wheel position alongside the calendar event that
+// produced it, so callers like the MQTT publisher can report more than a
+// bare position number.
+type WheelState struct {
+    Position     int
+    Label        string
+    EventSummary string
+    EventStart   string
+    EventEnd     string
 }
 
-func getCreds() (*oauth2.Config, *oauth2.Token, error) {
-    b, err := ioutil.ReadFile(CREDENTIALS_FILE)
+// calendarAuth holds the per-component paths to the Google OAuth client
+// secret and cached token, so Reconfigure can rewire credentials without
+// restarting the module. OAuthBindAddr travels alongside them instead of a
+// shared package-level global, so concurrent accounts/instances in the
+// same process each drive their own loopback callback server.
+type calendarAuth struct {
+    CredentialsFile string
+    TokenFile       string
+    OAuthBindAddr   string
+}
+
+func getCreds(auth *calendarAuth) (*oauth2.Config, *oauth2.Token, error) {
+    b, err := ioutil.ReadFile(auth.CredentialsFile)
     if err != nil {
         return nil, nil, fmt.Errorf("unable to read client secret file: %v", err)
     }
@@ -51,11 +55,10 @@ func getCreds() (*oauth2.Config, *oauth2.Token, error) {
         return nil, nil, fmt.Errorf("unable to parse client secret file to config: %v", err)
     }
 
-    tokFile := TOKEN_FILE
-    tok, err := tokenFromFile(tokFile)
+    tok, err := tokenFromFile(auth.TokenFile)
     if err != nil {
-        tok = getTokenFromWeb(config)
-        saveToken(tokFile, tok)
+        tok = getTokenFromWeb(config, auth.OAuthBindAddr)
+        saveToken(auth.TokenFile, tok)
     }
 
     return config, tok, nil
@@ -72,22 +75,6 @@ func tokenFromFile(file string) (*oauth2.Token, error) {
     return tok, err
 }
 
-func getTokenFromWeb(config *oauth2.Config) *oauth2.Token {
-    authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
-    fmt.Printf("Go to the following link in your browser then type the authorization code: \n%v\n", authURL)
-
-    var authCode string
-    if _, err := fmt.Scan(&authCode); err != nil {
-        log.Fatalf("Unable to read authorization code: %v", err)
-    }
-
-    tok, err := config.Exchange(context.TODO(), authCode)
-    if err != nil {
-        log.Fatalf("Unable to retrieve token from web: %v", err)
-    }
-    return tok
-}
-
 func saveToken(path string, token *oauth2.Token) {
     fmt.Printf("Saving credential file to: %s\n", path)
     f, err := os.Create(path)
@@ -98,29 +85,61 @@ func saveToken(path string, token *oauth2.Token) {
     json.NewEncoder(f).Encode(token)
 }
 
-func getNextWheelPosition() (int, error) {
-    config, tok, err := getCreds()
+// resolvedState builds a WheelState for a position index, filling in the
+// human-readable label from cfg.
+func resolvedState(cfg *WheelConfig, position int, summary, start, end string) WheelState {
+    return WheelState{
+        Position:     position,
+        Label:        cfg.Label(position),
+        EventSummary: summary,
+        EventStart:   start,
+        EventEnd:     end,
+    }
+}
+
+// namedOrDefault resolves a well-known label (e.g. "GOING_TO_EVENT") to a
+// position, falling back to the configured default if the label isn't
+// defined in this wheel's slices.
+func namedOrDefault(cfg *WheelConfig, label string) int {
+    if pos, ok := cfg.PositionIndex(label); ok {
+        return pos
+    }
+    return cfg.DefaultPositionIndex()
+}
+
+// newCalendarService builds an authenticated Calendar API client for auth,
+// running the OAuth flow if no cached token exists yet.
+func newCalendarService(auth *calendarAuth) (*calendar.Service, error) {
+    config, tok, err := getCreds(auth)
     if err != nil {
-        return AVAILABLE, err
+        return nil, err
     }
 
     client := config.Client(context.Background(), tok)
     srv, err := calendar.NewService(context.Background(), option.WithHTTPClient(client))
     if err != nil {
-        return AVAILABLE, fmt.Errorf("unable to retrieve Calendar client: %v", err)
+        return nil, fmt.Errorf("unable to retrieve Calendar client: %v", err)
+    }
+    return srv, nil
+}
+
+func getNextWheelPosition(cfg *WheelConfig, auth *calendarAuth, calendarID string) (WheelState, error) {
+    srv, err := newCalendarService(auth)
+    if err != nil {
+        return resolvedState(cfg, cfg.DefaultPositionIndex(), "", "", ""), err
     }
 
     now := time.Now().Format(time.RFC3339)
-    events, err := srv.Events.List("primary").ShowDeleted(false).
+    events, err := srv.Events.List(calendarID).ShowDeleted(false).
         SingleEvents(true).TimeMin(now).MaxResults(1).OrderBy("startTime").
         Do()
     if err != nil {
-        return AVAILABLE, fmt.Errorf("unable to retrieve next events: %v", err)
+        return resolvedState(cfg, cfg.DefaultPositionIndex(), "", "", ""), fmt.Errorf("unable to retrieve next events: %v", err)
     }
 
     if len(events.Items) == 0 {
         fmt.Println("No upcoming events found.")
-        return AVAILABLE, nil
+        return resolvedState(cfg, cfg.DefaultPositionIndex(), "", "", ""), nil
     }
 
     event := events.Items[0]
@@ -130,104 +149,56 @@ func getNextWheelPosition() (int, error) {
     if start == "" {
         start = event.Start.Date
     }
+    end := event.End.DateTime
+    if end == "" {
+        end = event.End.Date
+    }
+
+    // Rules with explicit match criteria take precedence over the built-in
+    // heuristics below, so e.g. a rule like "summary contains DO NOT
+    // DISTURB -> FOCUS_TIME" can override the default workingLocation/lead
+    // time behavior even for events that aren't starting imminently.
+    if pos, ok := cfg.ResolveOverride(event, calendarID); ok {
+        return resolvedState(cfg, pos, event.Summary, start, end), nil
+    }
 
     startTime, _ := time.Parse(time.RFC3339, start)
     if eventType == "workingLocation" {
         if event.Summary != "Office" {
-            return WORK_FROM_HOME, nil
+            return resolvedState(cfg, namedOrDefault(cfg, "WORK_FROM_HOME"), event.Summary, start, end), nil
         }
     } else if startTime.After(time.Now().Add(5 * time.Minute)) {
         fmt.Println("Next event is > 5 min from now, so AVAILABLE")
-        return AVAILABLE, nil
+        return resolvedState(cfg, cfg.DefaultPositionIndex(), event.Summary, start, end), nil
     } else if startTime.After(time.Now()) {
         fmt.Println("Next event is <= 5 min from now, so GOING_TO_EVENT")
-        return GOING_TO_EVENT, nil
+        return resolvedState(cfg, namedOrDefault(cfg, "GOING_TO_EVENT"), event.Summary, start, end), nil
     }
 
-    if pos, ok := eventTypeToWheelPosition[eventType]; ok {
-        return pos, nil
+    if pos, ok := cfg.Resolve(event, calendarID); ok {
+        return resolvedState(cfg, pos, event.Summary, start, end), nil
     }
 
-    return AVAILABLE, nil
-}
-
-func connect(apiKeyID, apiKey, robotAddress string) (*client.RobotClient, error) {
-	logger := logging.NewDebugLogger("client")
-	machine, err := client.New(
-		context.Background(),
-		robotAddress,
-		logger,
-		client.WithDialOptions(rpc.WithEntityCredentials( 
-			apiKeyID,
-			rpc.Credentials{
-				Type:    rpc.CredentialsTypeAPIKey, 
-				Payload: apiKey,
-			})),
-	)
-	if err != nil {
-		logger.Fatal(err)
-		return nil, err
-	}
-	
-	return machine, nil
+    return resolvedState(cfg, cfg.DefaultPositionIndex(), event.Summary, start, end), nil
 }
 
-func controlWheel(wheelMotor viam.Motor, currentWheelPosition int) (int, error) {
-    nextWheelPosition, err := getNextWheelPosition()
+func controlWheel(positioner *wheelPositioner, publisher *mqttPublisher, cfg *WheelConfig, accounts *AccountSet) (WheelState, error) {
+    nextState, err := accounts.NextWheelPosition(context.Background())
     if err != nil {
-        return currentWheelPosition, err
-    }
-
-    if currentWheelPosition != nextWheelPosition {
-        fmt.Printf("Turning wheel from %d to position %d\n", currentWheelPosition, nextWheelPosition)
-        slices := currentWheelPosition - nextWheelPosition
-        direction := int(math.Copysign(1, float64(slices)))
-        for i := 0; i < int(math.Abs(float64(slices))); i++ {
-            if err := wheelMotor.SetPower(context.Background(), -float64(direction)/6, nil); err != nil {
-                fmt.Println("Exception happened", err)
-                return currentWheelPosition, err
-            }
-            currentWheelPosition -= direction
-        }
+        return nextState, err
     }
 
-    return currentWheelPosition, nil
-}
-
-func main() {
-    apiKeyID := pflag.String("api-key-id", "", "The key id of the api key")
-	apiKey := pflag.String("api-key", "", "The api key")
-    robotAddress := pflag.String("robot-address", "", "Address of the robot")
-    pflag.Parse()
-
-    if *apiKeyID == "" || *apiKey == "" || *robotAddress == "" {
-        log.Fatal("api-key-id, api-key, and robot-address are required flags")
-    }
+    if positioner.Position() != nextState.Position {
+        fmt.Printf("Turning wheel from %d to position %d\n", positioner.Position(), nextState.Position)
+        if err := positioner.MoveTo(context.Background(), nextState.Position); err != nil {
+            fmt.Println("Exception happened", err)
+            return nextState, err
+        }
 
-    fmt.Println("Connecting to robot")
-    robot, err := connect(*apiKeyID, *apiKey, *robotAddress)
-    if err != nil {
-        log.Fatalf("Failed to connect to robot: %v", err)
-    }
-
-    fmt.Println("Turning wheel to initial position 0")
-	wheelMotor, err := motor.FromRobot(robot, "wheel_motor")
-	if err != nil {
-		log.Printf("Failed to find motor: %v", err)
-		return
-	}
-
-	for i := 0; i < 6; i++ {
-		_ = wheelMotor.SetPower(context.Background(), -1.0/6, nil)
-	}
-
-    currentWheelPosition := 0
-    for {
-        currentWheelPosition, err = controlWheel(wheelMotor, currentWheelPosition)
-        if err != nil {
-            log.Printf("Exception happened during turning, trying to recover: %v", err)
-        } else {
-            time.Sleep(1 * time.Minute)
+        if publisher != nil {
+            publisher.Publish(nextState)
         }
     }
+
+    return nextState, nil
 }
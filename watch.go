@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+const channelRenewalMargin = 5 * time.Minute
+
+// calendarWatcher replaces the 1-minute polling loop with a Google
+// Calendar push notification (watch) channel, so the wheel reacts as soon
+// as a meeting is created, accepted, declined, or moved. It needs a
+// non-empty webhookURL; presenceWheel only creates one when webhook_url is
+// configured and keeps polling otherwise.
+type calendarWatcher struct {
+	srv           *calendar.Service
+	calendarID    string
+	webhookURL    string
+	webhookBind   string
+	syncTokenPath string
+	onNotify      func()
+	logger        func(format string, args ...interface{})
+
+	mu         sync.Mutex
+	channelID  string
+	resourceID string
+	token      string
+	expiration time.Time
+	server     *http.Server
+}
+
+func newCalendarWatcher(srv *calendar.Service, calendarID, webhookURL, webhookBind, syncTokenPath string, onNotify func()) *calendarWatcher {
+	return &calendarWatcher{
+		srv:           srv,
+		calendarID:    calendarID,
+		webhookURL:    webhookURL,
+		webhookBind:   webhookBind,
+		syncTokenPath: syncTokenPath,
+		onNotify:      onNotify,
+		logger:        log.Printf,
+	}
+}
+
+// Start registers the watch channel, stands up the webhook receiver, and
+// begins the auto-renewal loop. It returns once the channel is
+// successfully registered; renewal continues in the background until ctx
+// is cancelled.
+func (w *calendarWatcher) Start(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", w.handleNotification)
+	w.server = &http.Server{Addr: w.webhookBind, Handler: mux}
+	go func() {
+		if err := w.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			w.logger("calendar webhook receiver stopped: %v", err)
+		}
+	}()
+
+	if err := w.registerChannel(ctx); err != nil {
+		return err
+	}
+
+	go w.renewLoop(ctx)
+	return nil
+}
+
+// Stop tears down the webhook receiver and the registered channel.
+func (w *calendarWatcher) Stop() {
+	w.mu.Lock()
+	channelID, resourceID := w.channelID, w.resourceID
+	server := w.server
+	w.mu.Unlock()
+
+	if channelID != "" {
+		_ = w.srv.Channels.Stop(&calendar.Channel{Id: channelID, ResourceId: resourceID}).Do()
+	}
+	if server != nil {
+		_ = server.Close()
+	}
+}
+
+func (w *calendarWatcher) registerChannel(ctx context.Context) error {
+	channelID, err := randomToken()
+	if err != nil {
+		return err
+	}
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.srv.Events.Watch(w.calendarID, &calendar.Channel{
+		Id:      channelID,
+		Type:    "web_hook",
+		Address: w.webhookURL,
+		Token:   token,
+	}).Do()
+	if err != nil {
+		return fmt.Errorf("unable to register calendar watch channel: %v", err)
+	}
+
+	w.mu.Lock()
+	oldChannelID, oldResourceID := w.channelID, w.resourceID
+	w.channelID = resp.Id
+	w.resourceID = resp.ResourceId
+	w.token = token
+	if resp.Expiration != 0 {
+		w.expiration = time.UnixMilli(resp.Expiration)
+	} else {
+		w.expiration = time.Now().Add(24 * time.Hour)
+	}
+	w.mu.Unlock()
+
+	// Stop the channel this one replaces now that the new one is
+	// confirmed, so renewal doesn't leak a live watch channel every cycle
+	// and eventually exhaust the account's watch-channel quota.
+	if oldChannelID != "" {
+		if err := w.srv.Channels.Stop(&calendar.Channel{Id: oldChannelID, ResourceId: oldResourceID}).Do(); err != nil {
+			w.logger("unable to stop superseded calendar watch channel %s: %v", oldChannelID, err)
+		}
+	}
+
+	return advanceSyncToken(w.srv, w.calendarID, w.syncTokenPath)
+}
+
+func (w *calendarWatcher) renewLoop(ctx context.Context) {
+	for {
+		w.mu.Lock()
+		wait := time.Until(w.expiration) - channelRenewalMargin
+		w.mu.Unlock()
+		if wait < 0 {
+			wait = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+			if err := w.registerChannel(ctx); err != nil {
+				w.logger("unable to renew calendar watch channel: %v", err)
+			}
+		}
+	}
+}
+
+func (w *calendarWatcher) handleNotification(rw http.ResponseWriter, r *http.Request) {
+	w.mu.Lock()
+	expectedChannelID, expectedToken := w.channelID, w.token
+	w.mu.Unlock()
+
+	if r.Header.Get("X-Goog-Channel-ID") != expectedChannelID || r.Header.Get("X-Goog-Channel-Token") != expectedToken {
+		http.Error(rw, "unrecognized channel", http.StatusForbidden)
+		return
+	}
+	rw.WriteHeader(http.StatusOK)
+
+	if r.Header.Get("X-Goog-Resource-State") == "sync" {
+		// Initial confirmation sent when the channel is created; no event
+		// has actually changed yet.
+		return
+	}
+
+	if err := advanceSyncToken(w.srv, w.calendarID, w.syncTokenPath); err != nil {
+		w.logger("unable to advance calendar sync token: %v", err)
+	}
+	w.onNotify()
+}
+
+// advanceSyncToken performs an incremental sync against the calendar and
+// persists the resulting token, so the next sync (in this run or a future
+// one) only needs to look at what changed.
+func advanceSyncToken(srv *calendar.Service, calendarID, syncTokenPath string) error {
+	call := srv.Events.List(calendarID).ShowDeleted(true).SingleEvents(true)
+	if token, err := loadSyncToken(syncTokenPath); err == nil && token != "" {
+		call = call.SyncToken(token)
+	} else {
+		call = call.TimeMin(time.Now().Format(time.RFC3339))
+	}
+
+	resp, err := call.Do()
+	if err != nil {
+		// A 410 Gone means the stored token expired; drop it so the next
+		// call does a fresh full sync instead of failing forever.
+		os.Remove(syncTokenPath)
+		return err
+	}
+	if resp.NextSyncToken != "" {
+		return saveSyncToken(syncTokenPath, resp.NextSyncToken)
+	}
+	return nil
+}
+
+func loadSyncToken(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func saveSyncToken(path, token string) error {
+	return os.WriteFile(path, []byte(token), 0644)
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.viam.com/rdk/components/board"
+	"go.viam.com/rdk/components/motor"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/services/generic"
+)
+
+const (
+	defaultRPM           = 10.0
+	defaultStateFile     = "wheel_state.json"
+	defaultRehomeEvery   = 50
+	defaultWebhookBind   = ":8090"
+	defaultSyncTokenFile = "sync_token.txt"
+)
+
+// Model is the model under which this component registers itself with a
+// Viam machine, e.g. in a robot config:
+//
+//	"model": "michaellee1019:workin-wheel:presence-wheel"
+var Model = resource.NewModel("michaellee1019", "workin-wheel", "presence-wheel")
+
+func init() {
+	resource.RegisterComponent(
+		generic.API,
+		Model,
+		resource.Registration[generic.Service, *Config]{
+			Constructor: newPresenceWheel,
+		},
+	)
+}
+
+// Config is the component's user-supplied configuration, set via the
+// robot config's "attributes" for this component.
+type Config struct {
+	MotorName       string `json:"motor_name"`
+	CredentialsFile string `json:"credentials_file"`
+	TokenFile       string `json:"token_file"`
+	SliceCount      int    `json:"slice_count"`
+	MappingConfig   string `json:"mapping_config"`
+	OAuthBind       string `json:"oauth_bind"`
+
+	// Accounts lists the calendars to aggregate across. When empty, the
+	// component uses the accounts registered with `workin-wheel --account
+	// add`, and if there are none, falls back to a single account built from
+	// CredentialsFile/TokenFile above.
+	Accounts []AccountConfig `json:"accounts"`
+
+	BoardName         string  `json:"board_name"`
+	HomeInterruptName string  `json:"home_interrupt_name"`
+	RPM               float64 `json:"rpm"`
+	StateFile         string  `json:"state_file"`
+	RehomeEvery       int     `json:"rehome_every"`
+
+	WebhookURL    string `json:"webhook_url"`
+	WebhookBind   string `json:"webhook_bind"`
+	SyncTokenFile string `json:"sync_token_file"`
+
+	MQTTBroker   string `json:"mqtt_broker"`
+	MQTTTopic    string `json:"mqtt_topic"`
+	MQTTUsername string `json:"mqtt_username"`
+	MQTTPassword string `json:"mqtt_password"`
+	MQTTQoS      int    `json:"mqtt_qos"`
+}
+
+// Validate checks the config and returns the names of components this
+// resource depends on.
+func (cfg *Config) Validate(path string) ([]string, error) {
+	if cfg.MotorName == "" {
+		return nil, fmt.Errorf("%s: motor_name is required", path)
+	}
+	deps := []string{cfg.MotorName}
+	if cfg.BoardName != "" {
+		deps = append(deps, cfg.BoardName)
+	}
+	return deps, nil
+}
+
+// presenceWheel checks the user's calendar, on push notifications when
+// webhook_url is set and on an interval otherwise, and turns the wheel to
+// the position that reflects their current presence.
+type presenceWheel struct {
+	resource.Named
+	logger logging.Logger
+
+	mu          sync.Mutex
+	positioner  *wheelPositioner
+	wheelConfig *WheelConfig
+	accounts    *AccountSet
+	publisher   *mqttPublisher
+	watcher     *calendarWatcher
+
+	cancel context.CancelFunc
+}
+
+// runOnce fetches the next calendar event and moves the wheel to the
+// position it implies, publishing the result if MQTT is configured.
+func (w *presenceWheel) runOnce(ctx context.Context) {
+	w.mu.Lock()
+	positioner := w.positioner
+	wheelConfig := w.wheelConfig
+	accounts := w.accounts
+	publisher := w.publisher
+	w.mu.Unlock()
+
+	state, err := controlWheel(positioner, publisher, wheelConfig, accounts)
+	if err != nil {
+		w.logger.Errorf("exception happened during turning, trying to recover: %v", err)
+		return
+	}
+	if publisher != nil {
+		publisher.SetLastState(state)
+	}
+}
+
+func newPresenceWheel(ctx context.Context, deps resource.Dependencies, conf resource.Config, logger logging.Logger) (generic.Service, error) {
+	w := &presenceWheel{
+		Named:  conf.ResourceName().AsNamed(),
+		logger: logger,
+	}
+	if err := w.Reconfigure(ctx, deps, conf); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Reconfigure rewires the motor/board dependencies, calendar credentials,
+// wheel mapping, and MQTT publisher without restarting the module, and
+// restarts the polling loop against the new configuration.
+func (w *presenceWheel) Reconfigure(ctx context.Context, deps resource.Dependencies, conf resource.Config) error {
+	newConf, err := resource.NativeConfig[*Config](conf)
+	if err != nil {
+		return err
+	}
+
+	wheelMotor, err := motor.FromDependencies(deps, newConf.MotorName)
+	if err != nil {
+		return fmt.Errorf("unable to find motor %q: %v", newConf.MotorName, err)
+	}
+
+	var homeInterrupt board.DigitalInterrupt
+	if newConf.BoardName != "" && newConf.HomeInterruptName != "" {
+		b, err := board.FromDependencies(deps, newConf.BoardName)
+		if err != nil {
+			return fmt.Errorf("unable to find board %q: %v", newConf.BoardName, err)
+		}
+		homeInterrupt, err = b.DigitalInterruptByName(newConf.HomeInterruptName)
+		if err != nil {
+			return fmt.Errorf("unable to find digital interrupt %q: %v", newConf.HomeInterruptName, err)
+		}
+	}
+
+	var wheelConfig *WheelConfig
+	if newConf.MappingConfig != "" {
+		wheelConfig, err = loadWheelConfig(newConf.MappingConfig)
+		if err != nil {
+			return err
+		}
+	} else {
+		wheelConfig = defaultWheelConfig()
+	}
+	if newConf.SliceCount != 0 && newConf.SliceCount != wheelConfig.SliceCount {
+		return fmt.Errorf("slice_count %d does not match mapping_config slice_count %d", newConf.SliceCount, wheelConfig.SliceCount)
+	}
+
+	bindAddr, err := parseBindAddr(newConf.OAuthBind)
+	if err != nil {
+		return err
+	}
+
+	var publisher *mqttPublisher
+	if newConf.MQTTBroker != "" {
+		topic := newConf.MQTTTopic
+		if topic == "" {
+			topic = "workin-wheel/state"
+		}
+		publisher, err = newMQTTPublisher(newConf.MQTTBroker, topic, newConf.MQTTUsername, newConf.MQTTPassword, byte(newConf.MQTTQoS))
+		if err != nil {
+			return fmt.Errorf("unable to connect to MQTT broker: %v", err)
+		}
+	}
+
+	rpm := newConf.RPM
+	if rpm == 0 {
+		rpm = defaultRPM
+	}
+	stateFile := firstNonEmpty(newConf.StateFile, defaultStateFile)
+	rehomeEvery := newConf.RehomeEvery
+	if rehomeEvery == 0 {
+		rehomeEvery = defaultRehomeEvery
+	}
+	positioner := newWheelPositioner(wheelMotor, homeInterrupt, stateFile, wheelConfig.SliceCount, rpm, rehomeEvery, wheelConfig.Slices)
+	if err := positioner.Init(ctx); err != nil {
+		return err
+	}
+
+	accountConfigs := newConf.Accounts
+	if len(accountConfigs) == 0 {
+		// Fall back to accounts registered via `workin-wheel --account add`,
+		// so the CLI manifest actually drives the running component instead
+		// of being shadowed by the component config's own Accounts field.
+		accountConfigs, err = loadAccountManifest()
+		if err != nil {
+			return err
+		}
+	}
+	if len(accountConfigs) == 0 {
+		accountConfigs = []AccountConfig{{
+			Name:            "default",
+			CalendarID:      PRIMARY_CALENDAR,
+			CredentialsFile: firstNonEmpty(newConf.CredentialsFile, CREDENTIALS_FILE),
+			TokenFile:       firstNonEmpty(newConf.TokenFile, TOKEN_FILE),
+			IsWork:          true,
+		}}
+	}
+	accounts := newAccountSet(wheelConfig, accountConfigs, bindAddr)
+
+	var watcher *calendarWatcher
+	if newConf.WebhookURL != "" {
+		if len(accountConfigs) != 1 {
+			w.logger.Warnf("webhook_url is set but %d accounts are configured; push notifications only support a single account, falling back to polling", len(accountConfigs))
+		} else {
+			srv, err := newCalendarService(accountConfigs[0].auth(bindAddr))
+			if err != nil {
+				return err
+			}
+			watcher = newCalendarWatcher(
+				srv,
+				accountConfigs[0].CalendarID,
+				newConf.WebhookURL,
+				firstNonEmpty(newConf.WebhookBind, defaultWebhookBind),
+				firstNonEmpty(newConf.SyncTokenFile, defaultSyncTokenFile),
+				func() { w.runOnce(context.Background()) },
+			)
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.cancel != nil {
+		w.cancel()
+	}
+	if w.watcher != nil {
+		w.watcher.Stop()
+	}
+	if w.publisher != nil {
+		w.publisher.Close()
+	}
+
+	w.positioner = positioner
+	w.wheelConfig = wheelConfig
+	w.accounts = accounts
+	w.publisher = publisher
+	w.watcher = watcher
+
+	pollCtx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+
+	if watcher != nil {
+		if err := watcher.Start(pollCtx); err != nil {
+			return fmt.Errorf("unable to start calendar watch: %v", err)
+		}
+		go w.runOnce(pollCtx)
+	} else {
+		go w.pollLoop(pollCtx)
+	}
+	if publisher != nil {
+		// pollCtx is cancelled by the next Reconfigure/Close, so KeepAlive
+		// stops alongside the publisher it's keeping alive instead of
+		// leaking a goroutine that publishes through a disconnected client.
+		go publisher.KeepAlive(pollCtx, 1*time.Minute)
+	}
+
+	return nil
+}
+
+// pollLoop is the fallback used when webhook_url is not configured: it
+// calls controlWheel on an interval until ctx is cancelled by a
+// subsequent Reconfigure or Close.
+func (w *presenceWheel) pollLoop(ctx context.Context) {
+	for {
+		w.runOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(1 * time.Minute):
+		}
+	}
+}
+
+// DoCommand supports {"command": "home"}, {"command": "set_position",
+// "position": N}, and {"command": "current"}.
+func (w *presenceWheel) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	command, _ := cmd["command"].(string)
+	switch command {
+	case "home":
+		return w.home(ctx)
+	case "set_position":
+		return w.setPosition(ctx, cmd)
+	case "current":
+		return w.current()
+	default:
+		return nil, fmt.Errorf("unrecognized command %q", command)
+	}
+}
+
+func (w *presenceWheel) home(ctx context.Context) (map[string]interface{}, error) {
+	w.mu.Lock()
+	positioner := w.positioner
+	w.mu.Unlock()
+
+	if err := positioner.Home(ctx); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"position": positioner.Position()}, nil
+}
+
+func (w *presenceWheel) setPosition(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	raw, ok := cmd["position"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("set_position requires a numeric \"position\"")
+	}
+	target := int(raw)
+
+	w.mu.Lock()
+	positioner := w.positioner
+	wheelConfig := w.wheelConfig
+	w.mu.Unlock()
+
+	if target < 0 || target >= wheelConfig.SliceCount {
+		return nil, fmt.Errorf("position %d out of range [0,%d)", target, wheelConfig.SliceCount)
+	}
+
+	if err := positioner.MoveTo(ctx, target); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{"position": positioner.Position(), "label": wheelConfig.Label(positioner.Position())}, nil
+}
+
+func (w *presenceWheel) current() (map[string]interface{}, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return map[string]interface{}{
+		"position": w.positioner.Position(),
+		"label":    w.wheelConfig.Label(w.positioner.Position()),
+	}, nil
+}
+
+// Close stops the polling loop and disconnects the MQTT publisher, if any.
+func (w *presenceWheel) Close(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cancel != nil {
+		w.cancel()
+	}
+	if w.watcher != nil {
+		w.watcher.Stop()
+	}
+	if w.publisher != nil {
+		w.publisher.Close()
+	}
+	return nil
+}
+
+func firstNonEmpty(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}